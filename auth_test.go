@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestComputeSignatureCoversQueryString(t *testing.T) {
+	body := []byte(`{}`)
+	a := computeSignature("secret", "GET", "/runs?job_id=A", "1000", "nonce", body)
+	b := computeSignature("secret", "GET", "/runs?job_id=B", "1000", "nonce", body)
+	if a == b {
+		t.Fatal("signatures for different query strings must differ")
+	}
+}
+
+func TestSignAndVerifyRequestRoundTrip(t *testing.T) {
+	body := []byte(`{"hello":"world"}`)
+	req := httptest.NewRequest("POST", "/runs?job_id=A", bytes.NewReader(body))
+	if err := signRequest(req, []string{"secret"}, body); err != nil {
+		t.Fatalf("signRequest: %v", err)
+	}
+	got, err := verifyRequest(req, []string{"secret"}, newNonceCache())
+	if err != nil {
+		t.Fatalf("verifyRequest: %v", err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatalf("verifyRequest body = %q, want %q", got, body)
+	}
+}
+
+func TestVerifyRequestRejectsQueryTampering(t *testing.T) {
+	body := []byte(`{}`)
+	req := httptest.NewRequest("POST", "/runs?job_id=A", bytes.NewReader(body))
+	if err := signRequest(req, []string{"secret"}, body); err != nil {
+		t.Fatalf("signRequest: %v", err)
+	}
+	req.URL.RawQuery = "job_id=B"
+	if _, err := verifyRequest(req, []string{"secret"}, newNonceCache()); err == nil {
+		t.Fatal("expected verifyRequest to reject a request with a rewritten query string")
+	}
+}
+
+func TestVerifyRequestRejectsReplayedNonce(t *testing.T) {
+	body := []byte(`{}`)
+	req := httptest.NewRequest("POST", "/runs", bytes.NewReader(body))
+	if err := signRequest(req, []string{"secret"}, body); err != nil {
+		t.Fatalf("signRequest: %v", err)
+	}
+	nonces := newNonceCache()
+	if _, err := verifyRequest(req, []string{"secret"}, nonces); err != nil {
+		t.Fatalf("first verifyRequest: %v", err)
+	}
+	replay := httptest.NewRequest("POST", "/runs", bytes.NewReader(body))
+	replay.Header = req.Header.Clone()
+	if _, err := verifyRequest(replay, []string{"secret"}, nonces); err == nil {
+		t.Fatal("expected verifyRequest to reject a replayed nonce")
+	}
+}
+
+func TestNonceCacheSeenRecently(t *testing.T) {
+	c := newNonceCache()
+	if c.seenRecently("a") {
+		t.Fatal("first sighting of a nonce should not be reported as seen")
+	}
+	if !c.seenRecently("a") {
+		t.Fatal("second sighting of the same nonce should be reported as seen")
+	}
+}
+
+func TestNonceCacheEvictsOldest(t *testing.T) {
+	c := newNonceCache()
+	for i := 0; i < nonceCacheSize; i++ {
+		c.seenRecently(strconv.Itoa(i))
+	}
+	// Cache is now full; one more insertion should evict nonce "0".
+	c.seenRecently(strconv.Itoa(nonceCacheSize))
+	if c.seenRecently("0") {
+		t.Fatal("expected oldest nonce to have been evicted, but it was reported as already seen")
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/morgangallant/scheduler/prisma/db"
+)
+
+// eventType enumerates the job lifecycle transitions subscribers can
+// register for.
+type eventType string
+
+const (
+	eventCreated      eventType = "created"
+	eventStarted      eventType = "started"
+	eventSucceeded    eventType = "succeeded"
+	eventFailed       eventType = "failed"
+	eventRetrying     eventType = "retrying"
+	eventDeadLettered eventType = "dead_lettered"
+	eventDeleted      eventType = "deleted"
+)
+
+// jobEvent is the payload POSTed to every matching subscriber.
+type jobEvent struct {
+	Event     eventType `json:"event"`
+	JobID     string    `json:"job_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Attempt   int       `json:"attempt"`
+	Error     string    `json:"error,omitempty"`
+}
+
+const (
+	eventChannelBuffer      = 1024
+	eventWorkerCount        = 4
+	maxDeliveryAttempts     = 3
+	circuitBreakerThreshold = 5
+
+	// eventDeliveryTimeout bounds a single webhook POST. Without it, a
+	// subscriber that accepts the connection but never responds ties up
+	// one of only eventWorkerCount drain goroutines forever, and because
+	// it never errors it never counts toward the circuit breaker either -
+	// enough such subscribers stall delivery to every subscriber, healthy
+	// ones included.
+	eventDeliveryTimeout = 10 * time.Second
+)
+
+// eventBus publishes job lifecycle events to subscriber webhooks
+// asynchronously: publish() only enqueues onto a buffered channel, so a
+// slow or dead subscriber can't block the scheduler's core loop. A small
+// pool of worker goroutines drains the channel and delivers to every
+// subscription whose event mask matches.
+type eventBus struct {
+	client *db.PrismaClient
+	events chan jobEvent
+}
+
+func newEventBus(client *db.PrismaClient) *eventBus {
+	eb := &eventBus{client: client, events: make(chan jobEvent, eventChannelBuffer)}
+	for i := 0; i < eventWorkerCount; i++ {
+		go eb.drain()
+	}
+	return eb
+}
+
+func (eb *eventBus) publish(evt jobEvent) {
+	select {
+	case eb.events <- evt:
+	default:
+		log.Printf("Event bus full, dropping %s event for job %s.", evt.Event, evt.JobID)
+	}
+}
+
+func (eb *eventBus) drain() {
+	for evt := range eb.events {
+		eb.deliver(evt)
+	}
+}
+
+func (eb *eventBus) deliver(evt jobEvent) {
+	ctx := context.TODO()
+	subs, err := eb.client.Subscription.FindMany(
+		db.Subscription.Disabled.Equals(false),
+	).Exec(ctx)
+	if err != nil {
+		log.Printf("Failed to load subscriptions: %v", err)
+		return
+	}
+	body, err := json.Marshal(evt)
+	if err != nil {
+		log.Printf("Failed to marshal %s event for job %s: %v", evt.Event, evt.JobID, err)
+		return
+	}
+	for _, sub := range subs {
+		if !hasTag(sub.EventMask, string(evt.Event)) {
+			continue
+		}
+		eb.deliverOne(ctx, sub, body)
+	}
+}
+
+// subscriptionFailureUpdate computes a subscriber's new consecutive
+// failure count and whether it should now be circuit-broken, given
+// whether the latest delivery succeeded. Split out from deliverOne so
+// this decision can be tested without a database.
+func subscriptionFailureUpdate(consecutiveFailures int, delivered bool) (failures int, disable bool) {
+	if delivered {
+		return 0, false
+	}
+	failures = consecutiveFailures + 1
+	return failures, failures >= circuitBreakerThreshold
+}
+
+// deliverOne retries a single subscriber up to maxDeliveryAttempts times
+// with exponential backoff, then trips the subscription's circuit
+// breaker once circuitBreakerThreshold consecutive deliveries have
+// failed.
+func (eb *eventBus) deliverOne(ctx context.Context, sub db.SubscriptionModel, body []byte) {
+	var deliveryErr error
+	for attempt := 0; attempt < maxDeliveryAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffDuration(defaultBackoffBaseMs, attempt))
+		}
+		if deliveryErr = eb.attemptDeliver(sub, body); deliveryErr == nil {
+			break
+		}
+	}
+	failures, disable := subscriptionFailureUpdate(sub.ConsecutiveFailures, deliveryErr == nil)
+	if deliveryErr == nil && sub.ConsecutiveFailures == 0 {
+		return
+	}
+	params := []db.SubscriptionSetParam{db.Subscription.ConsecutiveFailures.Set(failures)}
+	if disable {
+		params = append(params, db.Subscription.Disabled.Set(true))
+		log.Printf("Disabling subscription %s after %d consecutive failures.", sub.ID, failures)
+	}
+	if _, err := eb.client.Subscription.FindUnique(
+		db.Subscription.ID.Equals(sub.ID),
+	).Update(params...).Exec(ctx); err != nil {
+		log.Printf("Failed to update subscription %s: %v", sub.ID, err)
+	}
+}
+
+func (eb *eventBus) attemptDeliver(sub db.SubscriptionModel, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), eventDeliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if err := signRequest(req, []string{sub.Secret}, body); err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("subscriber returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (ws *webs) subscriptionsHandler() http.HandlerFunc {
+	type createRequest struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+		Secret string   `json:"secret"`
+	}
+	type subscription struct {
+		ID     string   `json:"id"`
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		switch r.Method {
+		case http.MethodGet:
+			subs, err := ws.sched.client.Subscription.FindMany().Exec(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			out := make([]subscription, 0, len(subs))
+			for _, sub := range subs {
+				out = append(out, subscription{ID: sub.ID, URL: sub.URL, Events: strings.Split(sub.EventMask, ",")})
+			}
+			if err := json.NewEncoder(w).Encode(out); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		case http.MethodPost:
+			var req createRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if req.URL == "" || req.Secret == "" || len(req.Events) == 0 {
+				http.Error(w, "url, secret, and at least one event are required", http.StatusBadRequest)
+				return
+			}
+			created, err := ws.sched.client.Subscription.CreateOne(
+				db.Subscription.URL.Set(req.URL),
+				db.Subscription.EventMask.Set(strings.Join(req.Events, ",")),
+				db.Subscription.Secret.Set(req.Secret),
+			).Exec(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err := json.NewEncoder(w).Encode(subscription{ID: created.ID, URL: created.URL, Events: req.Events}); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		default:
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (ws *webs) subscriptionHandler() http.HandlerFunc {
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		id := strings.TrimPrefix(r.URL.Path, "/subscriptions/")
+		if id == "" {
+			http.Error(w, "missing subscription id", http.StatusBadRequest)
+			return
+		}
+		if r.Method != http.MethodDelete {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		_, err := ws.sched.client.Subscription.FindUnique(
+			db.Subscription.ID.Equals(id),
+		).Delete().Exec(r.Context())
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
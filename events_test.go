@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestSubscriptionFailureUpdateResetsOnDelivery(t *testing.T) {
+	failures, disable := subscriptionFailureUpdate(4, true)
+	if failures != 0 || disable {
+		t.Fatalf("subscriptionFailureUpdate(4, true) = (%d, %v), want (0, false)", failures, disable)
+	}
+}
+
+func TestSubscriptionFailureUpdateIncrementsOnFailure(t *testing.T) {
+	failures, disable := subscriptionFailureUpdate(1, false)
+	if failures != 2 || disable {
+		t.Fatalf("subscriptionFailureUpdate(1, false) = (%d, %v), want (2, false)", failures, disable)
+	}
+}
+
+func TestSubscriptionFailureUpdateTripsCircuitBreakerAtThreshold(t *testing.T) {
+	failures, disable := subscriptionFailureUpdate(circuitBreakerThreshold-1, false)
+	if failures != circuitBreakerThreshold || !disable {
+		t.Fatalf("subscriptionFailureUpdate(%d, false) = (%d, %v), want (%d, true)", circuitBreakerThreshold-1, failures, disable, circuitBreakerThreshold)
+	}
+}
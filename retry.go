@@ -0,0 +1,27 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Defaults applied to jobs which don't specify their own retry policy.
+const (
+	defaultMaxAttempts   = 3
+	defaultBackoffBaseMs = 1000
+	defaultTimeoutMs     = 30000
+)
+
+// backoffDuration computes an exponential backoff of baseMs*2^attempts
+// plus a random jitter in [0, baseMs], so retries spread out rather than
+// thundering back in lockstep.
+func backoffDuration(baseMs, attempts int) time.Duration {
+	if baseMs <= 0 {
+		baseMs = defaultBackoffBaseMs
+	}
+	base := time.Duration(baseMs) * time.Millisecond
+	backoff := time.Duration(float64(base) * math.Pow(2, float64(attempts)))
+	jitter := time.Duration(rand.Int63n(int64(base) + 1))
+	return backoff + jitter
+}
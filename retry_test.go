@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDurationDefaultsBaseMs(t *testing.T) {
+	got := backoffDuration(0, 0)
+	// With baseMs defaulted and attempts=0, backoff is exactly the base
+	// delay plus jitter in [0, base].
+	base := time.Duration(defaultBackoffBaseMs) * time.Millisecond
+	if got < base || got > 2*base {
+		t.Fatalf("backoffDuration(0, 0) = %s, want in [%s, %s]", got, base, 2*base)
+	}
+}
+
+func TestBackoffDurationGrowsWithAttempts(t *testing.T) {
+	const baseMs = 1000
+	base := time.Duration(baseMs) * time.Millisecond
+	for attempts := 0; attempts < 5; attempts++ {
+		got := backoffDuration(baseMs, attempts)
+		minWant := base * time.Duration(1<<attempts)
+		maxWant := minWant + base
+		if got < minWant || got > maxWant {
+			t.Fatalf("backoffDuration(%d, %d) = %s, want in [%s, %s]", baseMs, attempts, got, minWant, maxWant)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/morgangallant/scheduler/prisma/db"
+)
+
+// runLogExcerptBytes bounds how much of a job's response body gets
+// persisted per JobRun, so a chatty endpoint can't blow up the database.
+const runLogExcerptBytes = 4 * 1024
+
+const defaultRunRetentionDays = 30
+
+// runResult captures the outcome of a single dispatch attempt (whether
+// to a worker or a cron's configured endpoint), independent of whether
+// it succeeded, so callers can persist a JobRun either way.
+type runResult struct {
+	StatusCode  int
+	BodyExcerpt []byte
+	Err         error
+}
+
+// jobRunParams builds the optional JobRun fields for a single execution
+// attempt. Split out from recordJobRun so the branching (which fields a
+// given outcome sets) can be tested without a database.
+func jobRunParams(started, finished time.Time, result runResult) []db.JobRunSetParam {
+	params := []db.JobRunSetParam{
+		db.JobRun.StartedAt.Set(db.DateTime(started)),
+		db.JobRun.FinishedAt.Set(db.DateTime(finished)),
+	}
+	if result.StatusCode != 0 {
+		params = append(params, db.JobRun.StatusCode.Set(result.StatusCode))
+	}
+	if len(result.BodyExcerpt) > 0 {
+		params = append(params, db.JobRun.ResponseBodyExcerpt.Set(string(result.BodyExcerpt)))
+	}
+	if result.Err != nil {
+		params = append(params, db.JobRun.Error.Set(result.Err.Error()))
+	}
+	return params
+}
+
+// recordJobRun persists one execution attempt against jobID. jobID is
+// not a foreign key: it may reference a Job that's already been deleted,
+// or a Cron's id for cron-triggered executions.
+func recordJobRun(ctx context.Context, client *db.PrismaClient, jobID string, started time.Time, result runResult) error {
+	_, err := client.JobRun.CreateOne(
+		db.JobRun.JobID.Set(jobID),
+		jobRunParams(started, time.Now(), result)...,
+	).Exec(ctx)
+	return err
+}
+
+// runRunRetentionSweeper periodically deletes JobRun rows older than
+// retentionDays, until ctx is cancelled.
+func runRunRetentionSweeper(ctx context.Context, client *db.PrismaClient, retentionDays int) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		cutoff := db.DateTime(time.Now().AddDate(0, 0, -retentionDays))
+		if result, err := client.JobRun.FindMany(
+			db.JobRun.StartedAt.Before(cutoff),
+		).Delete().Exec(ctx); err != nil {
+			log.Printf("Run retention sweep failed: %v", err)
+		} else if result.Count > 0 {
+			log.Printf("Pruned %d run(s) older than %d days.", result.Count, retentionDays)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ws *webs) listRunsHandler() http.HandlerFunc {
+	type runSummary struct {
+		RunID      string  `json:"id"`
+		JobID      string  `json:"job_id"`
+		StartedAt  string  `json:"started_at"`
+		StatusCode *int    `json:"status_code,omitempty"`
+		Error      *string `json:"error,omitempty"`
+	}
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		jobID := r.URL.Query().Get("job_id")
+		if jobID == "" {
+			http.Error(w, "missing job_id", http.StatusBadRequest)
+			return
+		}
+		limit := 20
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+				limit = v
+			}
+		}
+		runs, err := ws.sched.client.JobRun.FindMany(
+			db.JobRun.JobID.Equals(jobID),
+		).OrderBy(
+			db.JobRun.StartedAt.Order(db.DESC),
+		).Take(limit).Exec(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		summaries := make([]runSummary, 0, len(runs))
+		for _, run := range runs {
+			summary := runSummary{
+				RunID:     run.ID,
+				JobID:     run.JobID,
+				StartedAt: time.Time(run.StartedAt).Format(time.RFC3339),
+			}
+			if statusCode, ok := run.StatusCode(); ok {
+				summary.StatusCode = &statusCode
+			}
+			if runErr, ok := run.Error(); ok {
+				summary.Error = &runErr
+			}
+			summaries = append(summaries, summary)
+		}
+		if err := json.NewEncoder(w).Encode(summaries); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (ws *webs) getRunLogHandler() http.HandlerFunc {
+	type response struct {
+		ResponseBodyExcerpt string `json:"response_body_excerpt,omitempty"`
+		Error               string `json:"error,omitempty"`
+	}
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/runs/"), "/log")
+		if id == "" {
+			http.Error(w, "missing run id", http.StatusBadRequest)
+			return
+		}
+		run, err := ws.sched.client.JobRun.FindUnique(
+			db.JobRun.ID.Equals(id),
+		).Exec(r.Context())
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var resp response
+		resp.ResponseBodyExcerpt, _ = run.ResponseBodyExcerpt()
+		resp.Error, _ = run.Error()
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJobRunParamsAlwaysSetsTimestamps(t *testing.T) {
+	params := jobRunParams(time.Now(), time.Now(), runResult{})
+	if len(params) != 2 {
+		t.Fatalf("len(params) = %d, want 2 (StartedAt, FinishedAt only)", len(params))
+	}
+}
+
+func TestJobRunParamsSetsStatusCodeOnlyWhenNonZero(t *testing.T) {
+	if got := len(jobRunParams(time.Now(), time.Now(), runResult{StatusCode: 0})); got != 2 {
+		t.Fatalf("zero status code: len(params) = %d, want 2", got)
+	}
+	if got := len(jobRunParams(time.Now(), time.Now(), runResult{StatusCode: 200})); got != 3 {
+		t.Fatalf("non-zero status code: len(params) = %d, want 3", got)
+	}
+}
+
+func TestJobRunParamsSetsExcerptOnlyWhenNonEmpty(t *testing.T) {
+	if got := len(jobRunParams(time.Now(), time.Now(), runResult{BodyExcerpt: nil})); got != 2 {
+		t.Fatalf("empty excerpt: len(params) = %d, want 2", got)
+	}
+	if got := len(jobRunParams(time.Now(), time.Now(), runResult{BodyExcerpt: []byte("ok")})); got != 3 {
+		t.Fatalf("non-empty excerpt: len(params) = %d, want 3", got)
+	}
+}
+
+func TestJobRunParamsSetsErrorOnlyWhenPresent(t *testing.T) {
+	if got := len(jobRunParams(time.Now(), time.Now(), runResult{Err: nil})); got != 2 {
+		t.Fatalf("nil error: len(params) = %d, want 2", got)
+	}
+	if got := len(jobRunParams(time.Now(), time.Now(), runResult{Err: errors.New("boom")})); got != 3 {
+		t.Fatalf("non-nil error: len(params) = %d, want 3", got)
+	}
+}
+
+func TestJobRunParamsCombinesAllFields(t *testing.T) {
+	result := runResult{StatusCode: 500, BodyExcerpt: []byte("oops"), Err: errors.New("boom")}
+	if got := len(jobRunParams(time.Now(), time.Now(), result)); got != 5 {
+		t.Fatalf("all fields set: len(params) = %d, want 5", got)
+	}
+}
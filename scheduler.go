@@ -10,6 +10,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -42,11 +45,35 @@ func endpoint() string {
 	panic("missing ENDPOINT environment variable")
 }
 
-func secret() string {
-	if s, ok := os.LookupEnv("SECRET"); ok {
-		return s
+// secrets returns the configured signing secrets, primary (newest)
+// first. Outbound requests sign with the first entry; inbound requests
+// accept a signature from any of them, so a secret can be rotated by
+// prepending the new one and dropping the old one only once it's no
+// longer in use.
+func secrets() []string {
+	s, ok := os.LookupEnv("SECRET")
+	if !ok {
+		panic("missing SECRET environment variable")
+	}
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	if len(out) == 0 {
+		panic("missing SECRET environment variable")
 	}
-	panic("missing SECRET environment variable")
+	return out
+}
+
+func runRetentionDays() int {
+	if v, ok := os.LookupEnv("RUN_RETENTION_DAYS"); ok {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			return days
+		}
+	}
+	return defaultRunRetentionDays
 }
 
 func run() error {
@@ -55,10 +82,16 @@ func run() error {
 		return err
 	}
 	defer client.Disconnect()
-	secret, endpoint := secret(), endpoint()
-	scheduler := newScheduler(client, secret, endpoint)
-	cs := newCrons(client, secret, endpoint)
+	secrets, endpoint := secrets(), endpoint()
+	scheduler := newScheduler(client, secrets, endpoint)
+	cs := newCrons(client, secrets, endpoint)
 	webServer := newWebServer(":"+port(), scheduler, cs)
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	defer stopSweep()
+	go runRunRetentionSweeper(sweepCtx, client, runRetentionDays())
+	if dir, interval, ok := snapshotConfig(); ok {
+		go runPeriodicSnapshots(sweepCtx, client, dir, interval)
+	}
 	return runServers(scheduler, cs, webServer)
 }
 
@@ -85,63 +118,198 @@ func runServers(servers ...server) error {
 type scheduler struct {
 	client   *db.PrismaClient
 	recomp   chan struct{}
-	secret   string
+	secrets  []string
 	endpoint string
+	workers  *workerPool
+	events   *eventBus
 }
 
-func newScheduler(client *db.PrismaClient, secret, endpoint string) *scheduler {
+func newScheduler(client *db.PrismaClient, secrets []string, endpoint string) *scheduler {
 	return &scheduler{
 		client:   client,
 		recomp:   make(chan struct{}),
-		secret:   secret,
+		secrets:  secrets,
 		endpoint: endpoint,
+		workers:  newWorkerPool(client),
+		events:   newEventBus(client),
 	}
 }
 
-const headerSecretKey = "Scheduler-Secret"
-
-func (s *scheduler) executeJob(job db.JobModel) error {
-	var rdr io.Reader
-	body, ok := job.Body()
-	if ok {
-		rdr = bytes.NewBuffer(body)
+// executeJob dispatches a job to the given worker's /run endpoint and
+// reports the outcome, including a captured response excerpt, so the
+// caller can persist a JobRun regardless of success or failure.
+func (s *scheduler) executeJob(job db.JobModel, worker db.WorkerModel) runResult {
+	var body []byte
+	if b, ok := job.Body(); ok {
+		body = b
 	}
-	req, err := http.NewRequest("POST", s.endpoint, rdr)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(job.TimeoutMs)*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", worker.ID+"/run", bytes.NewBuffer(body))
 	if err != nil {
-		return err
+		return runResult{Err: err}
+	}
+	if err := signRequest(req, s.secrets, body); err != nil {
+		return runResult{Err: err}
 	}
-	req.Header.Set(headerSecretKey, s.secret)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		return runResult{Err: err}
 	}
 	defer resp.Body.Close()
+	result := runResult{StatusCode: resp.StatusCode}
+	result.BodyExcerpt, _ = io.ReadAll(io.LimitReader(resp.Body, runLogExcerptBytes))
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("job failed with non-ok status code %d: %s", resp.StatusCode, resp.Status)
+		result.Err = fmt.Errorf("job failed with non-ok status code %d: %s", resp.StatusCode, resp.Status)
+		return result
+	}
+	log.Printf("Executed job %s on worker %s.", job.ID, worker.ID)
+	return result
+}
+
+// attemptJob picks an eligible worker for the job, dispatches it, and
+// moves the job to succeeded (and removes it) on success, or into the
+// retry/dead-letter path on failure. If no eligible worker is currently
+// available, the job is left pending for the next scheduler pass. Every
+// attempt is recorded as a JobRun regardless of outcome.
+func (s *scheduler) attemptJob(ctx context.Context, job db.JobModel) error {
+	tag, _ := job.Tag()
+	worker, ok, err := s.workers.pick(ctx, tag)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Printf("No eligible worker for job %s, leaving pending.", job.ID)
+		return nil
+	}
+	defer s.workers.release(worker.ID)
+	if _, err := s.client.Job.FindUnique(
+		db.Job.ID.Equals(job.ID),
+	).Update(
+		db.Job.Status.Set(string(statusInProgress)),
+		db.Job.WorkerID.Set(worker.ID),
+		db.Job.DispatchedAt.Set(db.DateTime(time.Now())),
+	).Exec(ctx); err != nil {
+		return err
+	}
+	s.events.publish(jobEvent{Event: eventStarted, JobID: job.ID, Timestamp: time.Now(), Attempt: job.Attempts + 1})
+	started := time.Now()
+	result := s.executeJob(job, worker)
+	if err := recordJobRun(ctx, s.client, job.ID, started, result); err != nil {
+		log.Printf("Failed to record run for job %s: %v", job.ID, err)
+	}
+	if result.Err != nil {
+		return s.recordFailure(ctx, job, result.Err)
+	}
+	s.events.publish(jobEvent{Event: eventSucceeded, JobID: job.ID, Timestamp: time.Now(), Attempt: job.Attempts + 1})
+	if _, err := s.client.Job.FindUnique(
+		db.Job.ID.Equals(job.ID),
+	).Delete().Exec(ctx); err != nil {
+		return err
 	}
-	log.Printf("Executed job %s.", job.ID)
 	return nil
 }
 
-func (s *scheduler) executePendingJobs() error {
-	ctx := context.TODO()
+// recordFailure bumps the job's attempt count and either schedules the
+// next retry with exponential backoff, or dead-letters it once
+// MaxAttempts has been exhausted.
+func (s *scheduler) recordFailure(ctx context.Context, job db.JobModel, cause error) error {
+	attempts := job.Attempts + 1
+	s.events.publish(jobEvent{Event: eventFailed, JobID: job.ID, Timestamp: time.Now(), Attempt: attempts, Error: cause.Error()})
+	if attempts >= job.MaxAttempts {
+		_, err := s.client.Job.FindUnique(
+			db.Job.ID.Equals(job.ID),
+		).Update(
+			db.Job.Attempts.Set(attempts),
+			db.Job.Status.Set(string(statusDeadLettered)),
+			db.Job.LastError.Set(cause.Error()),
+		).Exec(ctx)
+		s.events.publish(jobEvent{Event: eventDeadLettered, JobID: job.ID, Timestamp: time.Now(), Attempt: attempts, Error: cause.Error()})
+		return err
+	}
+	next := time.Now().Add(backoffDuration(job.BackoffBaseMs, attempts))
+	_, err := s.client.Job.FindUnique(
+		db.Job.ID.Equals(job.ID),
+	).Update(
+		db.Job.Attempts.Set(attempts),
+		db.Job.Status.Set(string(statusFailed)),
+		db.Job.NextAttemptAt.Set(db.DateTime(next)),
+		db.Job.LastError.Set(cause.Error()),
+	).Exec(ctx)
+	s.events.publish(jobEvent{Event: eventRetrying, JobID: job.ID, Timestamp: time.Now(), Attempt: attempts, Error: cause.Error()})
+	return err
+}
+
+// reclaimStaleInProgressJobs fails any job that's been in_progress for
+// longer than its own TimeoutMs, regardless of whether the worker it was
+// dispatched to is still sending heartbeats. This covers the case where
+// the coordinator itself crashed mid-dispatch (or the worker accepted the
+// job but silently hung): reclaimDeadWorkers alone can't catch it, since
+// it only acts on worker health, not on how long an individual job has
+// been running.
+func (s *scheduler) reclaimStaleInProgressJobs(ctx context.Context) error {
 	jobs, err := s.client.Job.FindMany(
-		db.Job.ScheduledFor.BeforeEquals(db.DateTime(time.Now())),
+		db.Job.Status.Equals(string(statusInProgress)),
 	).Exec(ctx)
 	if err != nil {
 		return err
 	}
+	now := time.Now()
 	for _, job := range jobs {
-		if err := s.executeJob(job); err != nil {
-			log.Printf("Failed to execute job %s: %v", job.ID, err)
+		dispatchedAt, ok := job.DispatchedAt()
+		if !ok {
+			continue
 		}
-		if _, err := s.client.Job.FindUnique(
-			db.Job.ID.Equals(job.ID),
-		).Delete().Exec(ctx); err != nil {
+		deadline := time.Time(dispatchedAt).Add(time.Duration(job.TimeoutMs) * time.Millisecond)
+		if now.Before(deadline) {
+			continue
+		}
+		if workerID, ok := job.WorkerID(); ok {
+			s.workers.release(workerID)
+		}
+		log.Printf("Job %s timed out after %dms without a result, reclaiming.", job.ID, job.TimeoutMs)
+		if err := s.recordFailure(ctx, job, fmt.Errorf("job timed out after %dms", job.TimeoutMs)); err != nil {
 			return err
 		}
 	}
-	log.Printf("Executed %d jobs.", len(jobs))
+	return nil
+}
+
+// executePendingJobs dispatches every due job concurrently, bounded by
+// each worker's advertised capacity (enforced by workerPool.pick), so
+// registering more workers actually increases throughput instead of
+// queuing behind a single in-flight dispatch.
+func (s *scheduler) executePendingJobs() error {
+	ctx := context.TODO()
+	now := db.DateTime(time.Now())
+	jobs, err := s.client.Job.FindMany(
+		db.Job.Or(
+			db.Job.And(
+				db.Job.Status.Equals(string(statusPending)),
+				db.Job.ScheduledFor.BeforeEquals(now),
+			),
+			db.Job.And(
+				db.Job.Status.Equals(string(statusFailed)),
+				db.Job.NextAttemptAt.BeforeEquals(now),
+			),
+		),
+	).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		job := job
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.attemptJob(ctx, job); err != nil {
+				log.Printf("Failed to execute job %s: %v", job.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+	log.Printf("Dispatched %d job(s).", len(jobs))
 	return nil
 }
 
@@ -163,6 +331,12 @@ func (s *scheduler) start() error {
 	log.Println("Started scheduler.")
 	for {
 		log.Println("Scheduler woke up.")
+		if err := s.workers.reclaimDeadWorkers(context.TODO()); err != nil {
+			return err
+		}
+		if err := s.reclaimStaleInProgressJobs(context.TODO()); err != nil {
+			return err
+		}
 		if err := s.executePendingJobs(); err != nil {
 			return err
 		}
@@ -186,15 +360,47 @@ func (s *scheduler) stop() {
 	log.Println("Closed scheduler.")
 }
 
-func (s *scheduler) createNewJob(ctx context.Context, on time.Time, body []byte) (string, error) {
+// retryPolicy captures the optional per-job overrides accepted by the
+// /insert handler; zero values fall back to the package defaults.
+type retryPolicy struct {
+	MaxAttempts   int
+	BackoffBaseMs int
+	TimeoutMs     int
+}
+
+func (rp retryPolicy) withDefaults() retryPolicy {
+	if rp.MaxAttempts <= 0 {
+		rp.MaxAttempts = defaultMaxAttempts
+	}
+	if rp.BackoffBaseMs <= 0 {
+		rp.BackoffBaseMs = defaultBackoffBaseMs
+	}
+	if rp.TimeoutMs <= 0 {
+		rp.TimeoutMs = defaultTimeoutMs
+	}
+	return rp
+}
+
+func (s *scheduler) createNewJob(ctx context.Context, on time.Time, body []byte, tag string, rp retryPolicy) (string, error) {
+	rp = rp.withDefaults()
+	optional := []db.JobSetParam{
+		db.Job.Body.Set(body),
+		db.Job.MaxAttempts.Set(rp.MaxAttempts),
+		db.Job.BackoffBaseMs.Set(rp.BackoffBaseMs),
+		db.Job.TimeoutMs.Set(rp.TimeoutMs),
+	}
+	if tag != "" {
+		optional = append(optional, db.Job.Tag.Set(tag))
+	}
 	created, err := s.client.Job.CreateOne(
 		db.Job.ScheduledFor.Set(db.DateTime(on)),
-		db.Job.Body.Set(body),
+		optional...,
 	).Exec(ctx)
 	if err != nil {
 		return "", err
 	}
 	log.Printf("New job with id %s.", created.ID)
+	s.events.publish(jobEvent{Event: eventCreated, JobID: created.ID, Timestamp: time.Now()})
 	s.recomp <- struct{}{}
 	return created.ID, nil
 }
@@ -208,6 +414,7 @@ func (s *scheduler) deleteFutureJob(ctx context.Context, id string) error {
 	} else if err != nil {
 		return err
 	}
+	s.events.publish(jobEvent{Event: eventDeleted, JobID: id, Timestamp: time.Now()})
 	s.recomp <- struct{}{}
 	log.Printf("Deleted job %s.", id)
 	return nil
@@ -217,15 +424,15 @@ type crons struct {
 	client   *db.PrismaClient
 	recomp   chan struct{}
 	endpoint string
-	secret   string
+	secrets  []string
 }
 
-func newCrons(client *db.PrismaClient, secret, endpoint string) *crons {
+func newCrons(client *db.PrismaClient, secrets []string, endpoint string) *crons {
 	return &crons{
 		client:   client,
 		recomp:   make(chan struct{}),
 		endpoint: endpoint,
-		secret:   secret,
+		secrets:  secrets,
 	}
 }
 
@@ -253,27 +460,31 @@ type cronJobRequest struct {
 	JobID string `json:"cron_id"`
 }
 
-func (cs *crons) executeCronJob(id string) error {
+func (cs *crons) executeCronJob(id string) runResult {
 	buf, err := json.Marshal(cronJobRequest{
 		JobID: id,
 	})
 	if err != nil {
-		return err
+		return runResult{Err: err}
 	}
 	req, err := http.NewRequest("POST", cs.endpoint, bytes.NewBuffer(buf))
 	if err != nil {
-		return err
+		return runResult{Err: err}
+	}
+	if err := signRequest(req, cs.secrets, buf); err != nil {
+		return runResult{Err: err}
 	}
-	req.Header.Set(headerSecretKey, cs.secret)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		return runResult{Err: err}
 	}
 	defer resp.Body.Close()
+	result := runResult{StatusCode: resp.StatusCode}
+	result.BodyExcerpt, _ = io.ReadAll(io.LimitReader(resp.Body, runLogExcerptBytes))
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("invalid status code %d returned: %s", resp.StatusCode, resp.Status)
+		result.Err = fmt.Errorf("invalid status code %d returned: %s", resp.StatusCode, resp.Status)
 	}
-	return nil
+	return result
 }
 
 func (cs *crons) clearCronJobs() error {
@@ -304,8 +515,13 @@ func (cs *crons) start() error {
 		for _, job := range jobs {
 			j := job
 			if _, err := client.AddFunc(j.Spec, func() {
-				if err := cs.executeCronJob(j.JobID); err != nil {
-					log.Printf("Failed to execute cron job %s (%s): %v", j.JobID, j.Spec, err)
+				started := time.Now()
+				result := cs.executeCronJob(j.JobID)
+				if err := recordJobRun(context.TODO(), cs.client, j.JobID, started, result); err != nil {
+					log.Printf("Failed to record run for cron job %s: %v", j.JobID, err)
+				}
+				if result.Err != nil {
+					log.Printf("Failed to execute cron job %s (%s): %v", j.JobID, j.Spec, result.Err)
 					return
 				}
 				log.Printf("Executed cron job %s (%s).", j.JobID, j.Spec)
@@ -330,15 +546,25 @@ type webs struct {
 	mux        *http.ServeMux
 	sched      *scheduler
 	cs         *crons
+	nonces     *nonceCache
 	underlying *http.Server
 }
 
 func newWebServer(addr string, s *scheduler, cs *crons) *webs {
-	ws := &webs{addr: addr, mux: http.NewServeMux(), sched: s, cs: cs}
+	ws := &webs{addr: addr, mux: http.NewServeMux(), sched: s, cs: cs, nonces: newNonceCache()}
 	ws.mux.HandleFunc("/", ws.rootHandler())
 	ws.mux.HandleFunc("/cron", ws.cronHandler())
 	ws.mux.HandleFunc("/insert", ws.insertHandler())
 	ws.mux.HandleFunc("/delete", ws.deleteHandler())
+	ws.mux.HandleFunc("/jobs/", ws.getJobHandler())
+	ws.mux.HandleFunc("/worker/register", ws.workerRegisterHandler())
+	ws.mux.HandleFunc("/worker/heartbeat", ws.workerHeartbeatHandler())
+	ws.mux.HandleFunc("/runs", ws.listRunsHandler())
+	ws.mux.HandleFunc("/runs/", ws.getRunLogHandler())
+	ws.mux.HandleFunc("/subscriptions", ws.subscriptionsHandler())
+	ws.mux.HandleFunc("/subscriptions/", ws.subscriptionHandler())
+	ws.mux.HandleFunc("/admin/snapshot", ws.snapshotHandler())
+	ws.mux.HandleFunc("/admin/restore", ws.restoreHandler())
 	return ws
 }
 
@@ -365,6 +591,20 @@ func (ws *webs) stop() {
 	log.Println("Closed web server.")
 }
 
+// authenticated wraps inner with HMAC signature verification, handing
+// it the already-consumed request body so it doesn't need to read
+// r.Body itself.
+func (ws *webs) authenticated(inner func(w http.ResponseWriter, r *http.Request, body []byte)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := verifyRequest(r, ws.sched.secrets, ws.nonces)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		inner(w, r, body)
+	}
+}
+
 func (ws *webs) rootHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, "Scheduler (github.com/morgangallant/scheduler) written by Morgan Gallant.")
@@ -373,23 +613,28 @@ func (ws *webs) rootHandler() http.HandlerFunc {
 
 func (ws *webs) insertHandler() http.HandlerFunc {
 	type request struct {
-		Timestamp time.Time       `json:"timestamp"`
-		Body      json.RawMessage `json:"body"`
+		Timestamp     time.Time       `json:"timestamp"`
+		Body          json.RawMessage `json:"body"`
+		Tag           string          `json:"tag"`
+		MaxAttempts   int             `json:"max_attempts"`
+		BackoffBaseMs int             `json:"backoff_base_ms"`
+		TimeoutMs     int             `json:"timeout_ms"`
 	}
 	type response struct {
 		JobID string `json:"id"`
 	}
-	return func(w http.ResponseWriter, r *http.Request) {
-		if secret := r.Header.Get(headerSecretKey); secret != ws.sched.secret {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
 		var req request
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := json.Unmarshal(body, &req); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		jid, err := ws.sched.createNewJob(r.Context(), req.Timestamp, req.Body)
+		rp := retryPolicy{
+			MaxAttempts:   req.MaxAttempts,
+			BackoffBaseMs: req.BackoffBaseMs,
+			TimeoutMs:     req.TimeoutMs,
+		}
+		jid, err := ws.sched.createNewJob(r.Context(), req.Timestamp, req.Body, req.Tag, rp)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -397,20 +642,101 @@ func (ws *webs) insertHandler() http.HandlerFunc {
 		if err := json.NewEncoder(w).Encode(response{JobID: jid}); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
+	})
+}
+
+func (ws *webs) getJobHandler() http.HandlerFunc {
+	type response struct {
+		JobID         string  `json:"id"`
+		Status        string  `json:"status"`
+		Attempts      int     `json:"attempts"`
+		MaxAttempts   int     `json:"max_attempts"`
+		LastError     *string `json:"last_error,omitempty"`
+		NextAttemptAt *string `json:"next_attempt_at,omitempty"`
+	}
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+		if id == "" {
+			http.Error(w, "missing job id", http.StatusBadRequest)
+			return
+		}
+		job, err := ws.sched.client.Job.FindUnique(
+			db.Job.ID.Equals(id),
+		).Exec(r.Context())
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp := response{
+			JobID:       job.ID,
+			Status:      job.Status,
+			Attempts:    job.Attempts,
+			MaxAttempts: job.MaxAttempts,
+		}
+		if lastError, ok := job.LastError(); ok {
+			resp.LastError = &lastError
+		}
+		if nextAttemptAt, ok := job.NextAttemptAt(); ok {
+			formatted := time.Time(nextAttemptAt).Format(time.RFC3339)
+			resp.NextAttemptAt = &formatted
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (ws *webs) workerRegisterHandler() http.HandlerFunc {
+	type request struct {
+		WorkerID string   `json:"worker_id"`
+		Capacity int      `json:"capacity"`
+		Tags     []string `json:"tags"`
+	}
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.WorkerID == "" || req.Capacity <= 0 {
+			http.Error(w, "worker_id and a positive capacity are required", http.StatusBadRequest)
+			return
+		}
+		if err := ws.sched.workers.register(r.Context(), req.WorkerID, req.Capacity, req.Tags); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Registered worker %s (capacity %d).", req.WorkerID, req.Capacity)
+	})
+}
+
+func (ws *webs) workerHeartbeatHandler() http.HandlerFunc {
+	type request struct {
+		WorkerID string `json:"worker_id"`
 	}
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := ws.sched.workers.heartbeat(r.Context(), req.WorkerID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	})
 }
 
 func (ws *webs) deleteHandler() http.HandlerFunc {
 	type request struct {
 		JobID string `json:"id"`
 	}
-	return func(w http.ResponseWriter, r *http.Request) {
-		if secret := r.Header.Get(headerSecretKey); secret != ws.sched.secret {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
 		var req request
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := json.Unmarshal(body, &req); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -418,20 +744,16 @@ func (ws *webs) deleteHandler() http.HandlerFunc {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-	}
+	})
 }
 
 func (ws *webs) cronHandler() http.HandlerFunc {
 	type request struct {
 		Jobs []cronJob `json:"jobs"`
 	}
-	return func(w http.ResponseWriter, r *http.Request) {
-		if secret := r.Header.Get(headerSecretKey); secret != ws.sched.secret {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
 		var req request
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := json.Unmarshal(body, &req); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -446,5 +768,5 @@ func (ws *webs) cronHandler() http.HandlerFunc {
 			}
 		}
 		ws.cs.recomp <- struct{}{} // Signal to the crons that it needs to recompute.
-	}
+	})
 }
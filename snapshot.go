@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/morgangallant/scheduler/prisma/db"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
+)
+
+// snapshot is a point-in-time export of everything needed to recover
+// the scheduler's state: scheduled jobs, cron definitions, and their
+// execution history.
+type snapshot struct {
+	TakenAt time.Time        `json:"taken_at"`
+	Jobs    []jobSnapshot    `json:"jobs"`
+	Crons   []cronSnapshot   `json:"crons"`
+	Runs    []jobRunSnapshot `json:"runs"`
+}
+
+type jobSnapshot struct {
+	ID            string     `json:"id"`
+	ScheduledFor  time.Time  `json:"scheduled_for"`
+	Body          []byte     `json:"body,omitempty"`
+	Attempts      int        `json:"attempts"`
+	MaxAttempts   int        `json:"max_attempts"`
+	BackoffBaseMs int        `json:"backoff_base_ms"`
+	TimeoutMs     int        `json:"timeout_ms"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	Status        string     `json:"status"`
+	LastError     *string    `json:"last_error,omitempty"`
+	WorkerID      *string    `json:"worker_id,omitempty"`
+	Tag           *string    `json:"tag,omitempty"`
+	DispatchedAt  *time.Time `json:"dispatched_at,omitempty"`
+}
+
+type cronSnapshot struct {
+	ID            string `json:"id"`
+	Specification string `json:"specification"`
+}
+
+type jobRunSnapshot struct {
+	ID                  string     `json:"id"`
+	JobID               string     `json:"job_id"`
+	StartedAt           time.Time  `json:"started_at"`
+	FinishedAt          *time.Time `json:"finished_at,omitempty"`
+	StatusCode          *int       `json:"status_code,omitempty"`
+	ResponseBodyExcerpt *string    `json:"response_body_excerpt,omitempty"`
+	Error               *string    `json:"error,omitempty"`
+}
+
+// buildSnapshot reads a consistent-enough view of all Job, Cron, and
+// JobRun rows to export.
+func buildSnapshot(ctx context.Context, client *db.PrismaClient) (*snapshot, error) {
+	jobs, err := client.Job.FindMany().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	crons, err := client.Cron.FindMany().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := client.JobRun.FindMany().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snap := &snapshot{TakenAt: time.Now()}
+	for _, j := range jobs {
+		js := jobSnapshot{
+			ID:            j.ID,
+			ScheduledFor:  time.Time(j.ScheduledFor),
+			Attempts:      j.Attempts,
+			MaxAttempts:   j.MaxAttempts,
+			BackoffBaseMs: j.BackoffBaseMs,
+			TimeoutMs:     j.TimeoutMs,
+			Status:        j.Status,
+		}
+		if body, ok := j.Body(); ok {
+			js.Body = body
+		}
+		if next, ok := j.NextAttemptAt(); ok {
+			t := time.Time(next)
+			js.NextAttemptAt = &t
+		}
+		if lastError, ok := j.LastError(); ok {
+			js.LastError = &lastError
+		}
+		if workerID, ok := j.WorkerID(); ok {
+			js.WorkerID = &workerID
+		}
+		if tag, ok := j.Tag(); ok {
+			js.Tag = &tag
+		}
+		if dispatchedAt, ok := j.DispatchedAt(); ok {
+			t := time.Time(dispatchedAt)
+			js.DispatchedAt = &t
+		}
+		snap.Jobs = append(snap.Jobs, js)
+	}
+	for _, c := range crons {
+		snap.Crons = append(snap.Crons, cronSnapshot{ID: c.ID, Specification: c.Specification})
+	}
+	for _, run := range runs {
+		rs := jobRunSnapshot{
+			ID:        run.ID,
+			JobID:     run.JobID,
+			StartedAt: time.Time(run.StartedAt),
+		}
+		if finishedAt, ok := run.FinishedAt(); ok {
+			t := time.Time(finishedAt)
+			rs.FinishedAt = &t
+		}
+		if statusCode, ok := run.StatusCode(); ok {
+			rs.StatusCode = &statusCode
+		}
+		if excerpt, ok := run.ResponseBodyExcerpt(); ok {
+			rs.ResponseBodyExcerpt = &excerpt
+		}
+		if runErr, ok := run.Error(); ok {
+			rs.Error = &runErr
+		}
+		snap.Runs = append(snap.Runs, rs)
+	}
+	return snap, nil
+}
+
+func writeSnapshot(w io.Writer, snap *snapshot) error {
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(snap); err != nil {
+		gz.Close()
+		return err
+	}
+	return gz.Close()
+}
+
+func readSnapshot(r io.Reader) (*snapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	var snap snapshot
+	if err := json.NewDecoder(gz).Decode(&snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// restoreSnapshot atomically replaces all Job, Cron, and JobRun rows
+// with the contents of snap.
+func restoreSnapshot(ctx context.Context, client *db.PrismaClient, snap *snapshot) error {
+	txs := []transaction.Param{
+		client.Job.FindMany().Delete().Tx(),
+		client.Cron.FindMany().Delete().Tx(),
+		client.JobRun.FindMany().Delete().Tx(),
+	}
+	for _, j := range snap.Jobs {
+		optional := []db.JobSetParam{
+			db.Job.ID.Set(j.ID),
+			db.Job.Attempts.Set(j.Attempts),
+			db.Job.MaxAttempts.Set(j.MaxAttempts),
+			db.Job.BackoffBaseMs.Set(j.BackoffBaseMs),
+			db.Job.TimeoutMs.Set(j.TimeoutMs),
+			db.Job.Status.Set(j.Status),
+		}
+		if j.Body != nil {
+			optional = append(optional, db.Job.Body.Set(j.Body))
+		}
+		if j.NextAttemptAt != nil {
+			optional = append(optional, db.Job.NextAttemptAt.Set(db.DateTime(*j.NextAttemptAt)))
+		}
+		if j.LastError != nil {
+			optional = append(optional, db.Job.LastError.Set(*j.LastError))
+		}
+		if j.WorkerID != nil {
+			optional = append(optional, db.Job.WorkerID.Set(*j.WorkerID))
+		}
+		if j.Tag != nil {
+			optional = append(optional, db.Job.Tag.Set(*j.Tag))
+		}
+		if j.DispatchedAt != nil {
+			optional = append(optional, db.Job.DispatchedAt.Set(db.DateTime(*j.DispatchedAt)))
+		}
+		txs = append(txs, client.Job.CreateOne(db.Job.ScheduledFor.Set(db.DateTime(j.ScheduledFor)), optional...).Tx())
+	}
+	for _, c := range snap.Crons {
+		txs = append(txs, client.Cron.CreateOne(
+			db.Cron.ID.Set(c.ID),
+			db.Cron.Specification.Set(c.Specification),
+		).Tx())
+	}
+	for _, r := range snap.Runs {
+		optional := []db.JobRunSetParam{
+			db.JobRun.ID.Set(r.ID),
+			db.JobRun.StartedAt.Set(db.DateTime(r.StartedAt)),
+		}
+		if r.FinishedAt != nil {
+			optional = append(optional, db.JobRun.FinishedAt.Set(db.DateTime(*r.FinishedAt)))
+		}
+		if r.StatusCode != nil {
+			optional = append(optional, db.JobRun.StatusCode.Set(*r.StatusCode))
+		}
+		if r.ResponseBodyExcerpt != nil {
+			optional = append(optional, db.JobRun.ResponseBodyExcerpt.Set(*r.ResponseBodyExcerpt))
+		}
+		if r.Error != nil {
+			optional = append(optional, db.JobRun.Error.Set(*r.Error))
+		}
+		txs = append(txs, client.JobRun.CreateOne(db.JobRun.JobID.Set(r.JobID), optional...).Tx())
+	}
+	return client.Prisma.Transaction(txs...).Exec(ctx)
+}
+
+const defaultSnapshotRetain = 10
+
+// snapshotConfig reads SNAPSHOT_DIR and SNAPSHOT_INTERVAL; the periodic
+// snapshot goroutine is only started when both are set.
+func snapshotConfig() (dir string, interval time.Duration, ok bool) {
+	dir, hasDir := os.LookupEnv("SNAPSHOT_DIR")
+	raw, hasInterval := os.LookupEnv("SNAPSHOT_INTERVAL")
+	if !hasDir || !hasInterval {
+		return "", 0, false
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Printf("Invalid SNAPSHOT_INTERVAL %q, periodic snapshots disabled: %v", raw, err)
+		return "", 0, false
+	}
+	return dir, interval, true
+}
+
+// runPeriodicSnapshots writes a timestamped snapshot to dir every
+// interval, pruning all but the defaultSnapshotRetain most recent, until
+// ctx is cancelled. dir may also be an S3-compatible URL in deployments
+// that mount one via a sidecar; this implementation only handles local
+// paths.
+func runPeriodicSnapshots(ctx context.Context, client *db.PrismaClient, dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := takeSnapshotToDir(ctx, client, dir); err != nil {
+			log.Printf("Periodic snapshot failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func takeSnapshotToDir(ctx context.Context, client *db.PrismaClient, dir string) error {
+	snap, err := buildSnapshot(ctx, client)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("snapshot-%s.json.gz", snap.TakenAt.UTC().Format("20060102T150405Z"))
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeSnapshot(f, snap); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("Wrote snapshot %s.", path)
+	return pruneOldSnapshots(dir)
+}
+
+func pruneOldSnapshots(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), "snapshot-") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > defaultSnapshotRetain {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func (ws *webs) snapshotHandler() http.HandlerFunc {
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		snap, err := buildSnapshot(r.Context(), ws.sched.client)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", `attachment; filename="snapshot.json.gz"`)
+		if err := writeSnapshot(w, snap); err != nil {
+			log.Printf("Failed to stream snapshot: %v", err)
+		}
+	})
+}
+
+func (ws *webs) restoreHandler() http.HandlerFunc {
+	return ws.authenticated(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		snap, err := readSnapshot(bytes.NewReader(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := restoreSnapshot(r.Context(), ws.sched.client, snap); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ws.sched.recomp <- struct{}{}
+		ws.cs.recomp <- struct{}{}
+		log.Printf("Restored snapshot taken at %s (%d jobs, %d crons, %d runs).", snap.TakenAt, len(snap.Jobs), len(snap.Crons), len(snap.Runs))
+	})
+}
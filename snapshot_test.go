@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteReadSnapshotRoundTrip(t *testing.T) {
+	want := &snapshot{
+		TakenAt: time.Now().UTC().Truncate(time.Second),
+		Jobs:    []jobSnapshot{{ID: "job-1", ScheduledFor: time.Now().UTC().Truncate(time.Second), Status: "pending"}},
+		Crons:   []cronSnapshot{{ID: "cron-1", Specification: "* * * * * *"}},
+		Runs:    []jobRunSnapshot{{ID: "run-1", JobID: "job-1", StartedAt: time.Now().UTC().Truncate(time.Second)}},
+	}
+	var buf bytes.Buffer
+	if err := writeSnapshot(&buf, want); err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+	got, err := readSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("readSnapshot: %v", err)
+	}
+	if !got.TakenAt.Equal(want.TakenAt) {
+		t.Errorf("TakenAt = %s, want %s", got.TakenAt, want.TakenAt)
+	}
+	if len(got.Jobs) != 1 || got.Jobs[0].ID != "job-1" {
+		t.Errorf("Jobs = %+v, want one job with ID job-1", got.Jobs)
+	}
+	if len(got.Crons) != 1 || got.Crons[0].ID != "cron-1" {
+		t.Errorf("Crons = %+v, want one cron with ID cron-1", got.Crons)
+	}
+	if len(got.Runs) != 1 || got.Runs[0].ID != "run-1" {
+		t.Errorf("Runs = %+v, want one run with ID run-1", got.Runs)
+	}
+}
+
+func TestReadSnapshotRejectsNonGzipInput(t *testing.T) {
+	if _, err := readSnapshot(bytes.NewReader([]byte("not gzip"))); err == nil {
+		t.Fatal("expected readSnapshot to reject non-gzip input")
+	}
+}
+
+// testSnapshotName mimics takeSnapshotToDir's naming scheme closely
+// enough for pruneOldSnapshots to exercise: a "snapshot-" prefix
+// followed by a zero-padded, lexicographically-sortable sequence
+// number in place of a real timestamp.
+func testSnapshotName(i int) string {
+	return fmt.Sprintf("snapshot-%04d.json.gz", i)
+}
+
+func TestPruneOldSnapshotsRetainsOnlyTheNewest(t *testing.T) {
+	dir := t.TempDir()
+	const total = defaultSnapshotRetain + 3
+	for i := 0; i < total; i++ {
+		name := filepath.Join(dir, testSnapshotName(i))
+		if err := os.WriteFile(name, []byte("x"), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	if err := pruneOldSnapshots(dir); err != nil {
+		t.Fatalf("pruneOldSnapshots: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != defaultSnapshotRetain {
+		t.Fatalf("len(entries) = %d, want %d", len(entries), defaultSnapshotRetain)
+	}
+	if _, err := os.Stat(filepath.Join(dir, testSnapshotName(0))); !os.IsNotExist(err) {
+		t.Fatalf("expected the oldest snapshot to have been pruned, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, testSnapshotName(total-1))); err != nil {
+		t.Fatalf("expected the newest snapshot to survive: %v", err)
+	}
+}
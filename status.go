@@ -0,0 +1,14 @@
+package main
+
+// jobStatus models the lifecycle of a scheduled job, mirroring the
+// pending -> in_progress -> (succeeded | failed -> dead_lettered) states
+// a job moves through as the scheduler retries failed executions.
+type jobStatus string
+
+const (
+	statusPending      jobStatus = "pending"
+	statusInProgress   jobStatus = "in_progress"
+	statusFailed       jobStatus = "failed"
+	statusSucceeded    jobStatus = "succeeded"
+	statusDeadLettered jobStatus = "dead_lettered"
+)
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/morgangallant/scheduler/prisma/db"
+)
+
+// heartbeatInterval is the cadence workers are expected to hit
+// /worker/heartbeat at; heartbeatTimeout is how long a worker can go
+// silent before the coordinator considers it dead and reclaims its jobs.
+const (
+	heartbeatInterval = 15 * time.Second
+	heartbeatTimeout  = 3 * heartbeatInterval
+)
+
+// workerPool tracks registered workers and how many jobs are currently
+// in flight on each, so dispatch can round-robin across workers while
+// respecting their advertised capacity.
+type workerPool struct {
+	client *db.PrismaClient
+
+	mu       sync.Mutex
+	inFlight map[string]int
+	cursor   int
+}
+
+func newWorkerPool(client *db.PrismaClient) *workerPool {
+	return &workerPool{client: client, inFlight: make(map[string]int)}
+}
+
+// register upserts a worker's capacity and tags and (re)starts its
+// heartbeat clock; workers call this once on startup.
+func (wp *workerPool) register(ctx context.Context, id string, capacity int, tags []string) error {
+	tagStr := strings.Join(tags, ",")
+	_, err := wp.client.Worker.UpsertOne(
+		db.Worker.ID.Equals(id),
+	).Create(
+		db.Worker.ID.Set(id),
+		db.Worker.Capacity.Set(capacity),
+		db.Worker.Tags.Set(tagStr),
+		db.Worker.LastHeartbeatAt.Set(db.DateTime(time.Now())),
+	).Update(
+		db.Worker.Capacity.Set(capacity),
+		db.Worker.Tags.Set(tagStr),
+		db.Worker.LastHeartbeatAt.Set(db.DateTime(time.Now())),
+	).Exec(ctx)
+	return err
+}
+
+// heartbeat refreshes a worker's last-seen timestamp, keeping it
+// eligible for dispatch.
+func (wp *workerPool) heartbeat(ctx context.Context, id string) error {
+	_, err := wp.client.Worker.FindUnique(
+		db.Worker.ID.Equals(id),
+	).Update(
+		db.Worker.LastHeartbeatAt.Set(db.DateTime(time.Now())),
+	).Exec(ctx)
+	if errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("worker %s is not registered", id)
+	}
+	return err
+}
+
+// pick returns an eligible, non-saturated worker for the given tag
+// (empty matches any worker), cycling round-robin across the known set,
+// and provisionally bumps its in-flight counter.
+func (wp *workerPool) pick(ctx context.Context, tag string) (db.WorkerModel, bool, error) {
+	workers, err := wp.client.Worker.FindMany(
+		db.Worker.LastHeartbeatAt.After(db.DateTime(time.Now().Add(-heartbeatTimeout))),
+	).Exec(ctx)
+	if err != nil {
+		return db.WorkerModel{}, false, err
+	}
+	if len(workers) == 0 {
+		return db.WorkerModel{}, false, nil
+	}
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	for i := 0; i < len(workers); i++ {
+		idx := (wp.cursor + i) % len(workers)
+		w := workers[idx]
+		if tag != "" && !hasTag(w.Tags, tag) {
+			continue
+		}
+		if wp.inFlight[w.ID] >= w.Capacity {
+			continue
+		}
+		wp.cursor = (idx + 1) % len(workers)
+		wp.inFlight[w.ID]++
+		return w, true, nil
+	}
+	return db.WorkerModel{}, false, nil
+}
+
+// release gives back a slot claimed by pick, once the dispatched job
+// finishes (successfully or not).
+func (wp *workerPool) release(id string) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if wp.inFlight[id] > 0 {
+		wp.inFlight[id]--
+	}
+}
+
+func hasTag(tags, tag string) bool {
+	for _, t := range strings.Split(tags, ",") {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// reclaimDeadWorkers resets jobs left running on workers that have
+// missed their heartbeat back to pending, so another worker can pick
+// them up on the next scheduler pass.
+func (wp *workerPool) reclaimDeadWorkers(ctx context.Context) error {
+	dead, err := wp.client.Worker.FindMany(
+		db.Worker.LastHeartbeatAt.Before(db.DateTime(time.Now().Add(-heartbeatTimeout))),
+	).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, w := range dead {
+		result, err := wp.client.Job.FindMany(
+			db.Job.WorkerID.Equals(w.ID),
+			db.Job.Status.Equals(string(statusInProgress)),
+		).Update(
+			db.Job.Status.Set(string(statusPending)),
+			db.Job.WorkerID.Set(""),
+		).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		wp.mu.Lock()
+		delete(wp.inFlight, w.ID)
+		wp.mu.Unlock()
+		if result.Count > 0 {
+			log.Printf("Reclaimed %d job(s) from dead worker %s.", result.Count, w.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestHasTag(t *testing.T) {
+	cases := []struct {
+		tags, tag string
+		want      bool
+	}{
+		{"", "gpu", false},
+		{"gpu", "gpu", true},
+		{"gpu,fast", "fast", true},
+		{"gpu,fast", "slow", false},
+		{"gpu", "", false},
+	}
+	for _, c := range cases {
+		if got := hasTag(c.tags, c.tag); got != c.want {
+			t.Errorf("hasTag(%q, %q) = %v, want %v", c.tags, c.tag, got, c.want)
+		}
+	}
+}